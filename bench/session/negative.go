@@ -0,0 +1,104 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/fails"
+)
+
+// NegativeCase describes a single abuse-path HTTP call: the request
+// to send and the status code (and, optionally, error message) the
+// target is expected to respond with.
+type NegativeCase struct {
+	Method string
+	Path   string
+	Body   interface{}
+
+	ExpectStatus   int
+	ExpectErrorMsg string
+
+	// CSRFOverride, when non-empty, replaces the csrf_token field of
+	// the marshaled Body with this literal value instead of sending
+	// whatever Body.CSRFToken was set to.
+	CSRFOverride string
+
+	// AllowSideEffects opts this case in to being retried even though
+	// its Path has non-idempotent side effects (POST /sell, POST
+	// /buy, POST /ship). Leave false for anything that mutates state
+	// exactly once per call.
+	AllowSideEffects bool
+
+	FailPrefix string
+}
+
+// runNegative performs the marshal/POST/status-check/decode/compare
+// pipeline shared by every abuse-path helper in this file.
+func (s *Session) runNegative(ctx context.Context, c NegativeCase) error {
+	b, err := json.Marshal(c.Body)
+	if err != nil {
+		return fails.NewError(err, c.FailPrefix+"リクエストの作成に失敗しました")
+	}
+
+	if c.CSRFOverride != "" {
+		b, err = overrideCSRFToken(b, c.CSRFOverride)
+		if err != nil {
+			return fails.NewError(err, c.FailPrefix+"リクエストの作成に失敗しました")
+		}
+	}
+
+	var req *http.Request
+	if c.Method == http.MethodGet {
+		req, err = s.newGetRequest(ShareTargetURLs.AppURL, c.Path)
+	} else {
+		req, err = s.newPostRequest(ShareTargetURLs.AppURL, c.Path, "application/json", bytes.NewBuffer(b))
+	}
+	if err != nil {
+		return fails.NewError(err, c.FailPrefix+"リクエストに失敗しました")
+	}
+
+	res, retries, err := s.doWithRetry(ctx, req, c.AllowSideEffects)
+	if err != nil {
+		return fails.NewError(err, fmt.Sprintf("%sリクエストに失敗しました(リトライ%d回)", c.FailPrefix, retries))
+	}
+	defer res.Body.Close()
+
+	msg, err := checkStatusCode(res, c.ExpectStatus)
+	if err != nil {
+		return fails.NewError(err, fmt.Sprintf("%s%s(リトライ%d回)", c.FailPrefix, msg, retries))
+	}
+
+	re := resErr{}
+	if err := json.NewDecoder(res.Body).Decode(&re); err != nil {
+		return fails.NewError(err, c.FailPrefix+"JSONデコードに失敗しました")
+	}
+
+	if c.ExpectErrorMsg != "" && re.Error != c.ExpectErrorMsg {
+		return fails.NewError(fmt.Errorf("unexpected error message: %q", re.Error), c.FailPrefix+c.ExpectErrorMsg+"というエラーではありません")
+	}
+
+	return nil
+}
+
+// overrideCSRFToken replaces the csrf_token field of a marshaled
+// request body with token, so NegativeCase.CSRFOverride can inject a
+// deliberately wrong token without each wrapper building its own
+// throwaway Body value just to hold one.
+func overrideCSRFToken(body []byte, token string) ([]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	m["csrf_token"] = token
+	return json.Marshal(m)
+}
+
+// RunNegative exposes runNegative so scenario authors can add new
+// abuse cases (malformed JSON, oversized fields, wrong content-type,
+// ...) without copy-pasting the helpers in wrongapp.go.
+func (s *Session) RunNegative(ctx context.Context, c NegativeCase) error {
+	return s.runNegative(ctx, c)
+}
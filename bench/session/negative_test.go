@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestSession(appURL string) *Session {
+	ShareTargetURLs.AppURL = appURL
+	return NewSession(http.DefaultClient)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resErr{Error: msg})
+}
+
+func TestLoginWithWrongPassword(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusUnauthorized, "ログインIDかパスワードが間違えています")
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.LoginWithWrongPassword(context.Background(), "test", "wrong"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSellWithWrongCSRFToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusUnprocessableEntity, "csrf token error")
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.SellWithWrongCSRFToken(context.Background(), "item", 100, "desc", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSellWithWrongPrice(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusBadRequest, ItemPriceErrMsg)
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.SellWithWrongPrice(context.Background(), "item", 1, "desc", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuyWithWrongCSRFToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusUnprocessableEntity, "csrf token error")
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.BuyWithWrongCSRFToken(context.Background(), 1, "token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBuyWithFailed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusBadRequest, "item is not for sale")
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.BuyWithFailed(context.Background(), 1, "token", http.StatusBadRequest, "item is not for sale"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestShipWithWrongCSRFToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusUnprocessableEntity, "csrf token error")
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.ShipWithWrongCSRFToken(context.Background(), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestShipWithWrongSeller(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusForbidden, "権限がありません")
+	}))
+	defer ts.Close()
+
+	s := newTestSession(ts.URL)
+	if err := s.ShipWithWrongSeller(context.Background(), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
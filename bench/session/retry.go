@@ -0,0 +1,146 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how Session.doWithRetry retries a transiently
+// failing request: up to MaxRetries retries after the first attempt,
+// each delayed by min(MaxBackoff, MinBackoff*2^n) scaled by a random
+// jitter factor in [0.5, 1.0), so a delay can be as short as half of
+// MinBackoff.
+type RetryPolicy struct {
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 100 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.MinBackoff) * math.Pow(2, float64(attempt))
+	if max := float64(p.MaxBackoff); d > max {
+		d = max
+	}
+	jitter := 0.5 + rand.Float64()*0.5
+	return time.Duration(d * jitter)
+}
+
+// WithRetry returns a copy of s that retries transient HTTP failures
+// according to policy.
+func (s *Session) WithRetry(policy RetryPolicy) *Session {
+	clone := *s
+	clone.retry = &policy
+	return &clone
+}
+
+func isIdempotentRequest(req *http.Request) bool {
+	if req.Method != http.MethodPost {
+		return true
+	}
+	switch req.URL.Path {
+	case "/sell", "/buy", "/ship":
+		return false
+	default:
+		return true
+	}
+}
+
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		if errors.Is(err, io.EOF) {
+			return true
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		return false
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// doWithRetry buffers req's body so it can be replayed, then calls Do
+// up to policy.MaxRetries additional times whenever isRetryable says
+// the failure was transient. It reports how many retries it took so
+// callers can fold that count into their fails.NewError message.
+//
+// allowSideEffects opts this one call in to replaying requests with
+// non-idempotent side effects (POST /sell, POST /buy, POST /ship). It
+// is a per-call flag rather than part of RetryPolicy so that a
+// Session configured with WithRetry can retry most calls while still
+// sending a listing, purchase, or shipment at most once, unless that
+// specific call opts in.
+func (s *Session) doWithRetry(ctx context.Context, req *http.Request, allowSideEffects bool) (*http.Response, int, error) {
+	policy := defaultRetryPolicy
+	if s.retry != nil {
+		policy = *s.retry
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	rewind := func() {
+		if body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+	}
+
+	if !allowSideEffects && !isIdempotentRequest(req) {
+		rewind()
+		res, err := s.Do(ctx, req)
+		return res, 0, err
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+	for attempt := 0; attempt <= policy.MaxRetries; attempt++ {
+		rewind()
+		res, err = s.Do(ctx, req)
+		if !isRetryable(res, err) || attempt == policy.MaxRetries {
+			return res, attempt, err
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(policy.backoff(attempt)):
+		case <-ctx.Done():
+			return nil, attempt, ctx.Err()
+		}
+	}
+
+	return res, policy.MaxRetries, err
+}
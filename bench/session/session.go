@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// doResult carries the outcome of an in-flight httpClient.Do call
+// back from the goroutine in Do to whichever select branch handles
+// it, including the late-arriving discard path.
+type doResult struct {
+	res *http.Response
+	err error
+}
+
+// Session represents one user's interaction with the target
+// application: its HTTP client, CSRF token, and the read/write
+// deadlines applied to the requests it issues.
+type Session struct {
+	httpClient *http.Client
+	csrfToken  string
+
+	// readDeadline and writeDeadline are pointers so that a shallow
+	// copy of Session (as WithRetry makes) shares the same deadline
+	// state instead of copying sync.Mutex values and diverging from
+	// the original session.
+	readDeadline  *sessionDeadline
+	writeDeadline *sessionDeadline
+
+	retry *RetryPolicy
+}
+
+// NewSession returns a Session ready to issue requests with no
+// deadline configured.
+func NewSession(httpClient *http.Client) *Session {
+	return &Session{
+		httpClient:    httpClient,
+		readDeadline:  newSessionDeadline(),
+		writeDeadline: newSessionDeadline(),
+	}
+}
+
+// SetDeadline sets the read and write deadlines applied to requests
+// issued by Do, following the timer/cancel-channel pattern net uses
+// for its connection deadlines. A zero Time disables the respective
+// deadline.
+func (s *Session) SetDeadline(read, write time.Time) {
+	s.readDeadline.set(read)
+	s.writeDeadline.set(write)
+}
+
+func (s *Session) newPostRequest(rawURL, path, contentType string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, rawURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
+
+func (s *Session) newGetRequest(rawURL, path string) (*http.Request, error) {
+	return http.NewRequest(http.MethodGet, rawURL+path, nil)
+}
+
+// Do issues req, failing fast if ctx is canceled or either the
+// session's read or write deadline elapses before the target
+// responds. On a deadline or cancellation it cancels the in-flight
+// request rather than abandoning it, and drains+closes the response
+// if it arrives after Do has already returned.
+func (s *Session) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	done := make(chan doResult, 1)
+	go func() {
+		res, err := s.httpClient.Do(req)
+		done <- doResult{res, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		cancel()
+		go discard(done)
+		return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+	case <-s.readDeadline.wait():
+		cancel()
+		go discard(done)
+		return nil, fmt.Errorf("request exceeded read deadline")
+	case <-s.writeDeadline.wait():
+		cancel()
+		go discard(done)
+		return nil, fmt.Errorf("request exceeded write deadline")
+	}
+}
+
+// discard drains and closes a response that arrives after Do has
+// already returned on a cancellation or deadline branch, so the
+// underlying connection isn't leaked.
+func discard(done chan doResult) {
+	r := <-done
+	if r.res != nil {
+		io.Copy(ioutil.Discard, r.res.Body)
+		r.res.Body.Close()
+	}
+}
+
+func checkStatusCode(res *http.Response, expectedStatusCode int) (string, error) {
+	if res.StatusCode != expectedStatusCode {
+		return fmt.Sprintf("期待したステータスコードと違います(expected:%d actual:%d)", expectedStatusCode, res.StatusCode), fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+	return "", nil
+}
@@ -1,13 +1,10 @@
 package session
 
 import (
-	"bytes"
+	"context"
 	crand "crypto/rand"
-	"encoding/json"
 	"fmt"
 	"net/http"
-
-	"github.com/isucon/isucon9-qualify/bench/fails"
 )
 
 const (
@@ -28,233 +25,101 @@ func secureRandomStr(b int) string {
 	return fmt.Sprintf("%x", k)
 }
 
-func (s *Session) LoginWithWrongPassword(accountName, password string) error {
-	b, _ := json.Marshal(reqLogin{
-		AccountName: accountName,
-		Password:    password,
+func (s *Session) LoginWithWrongPassword(ctx context.Context, accountName, password string) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/login",
+		Body: reqLogin{
+			AccountName: accountName,
+			Password:    password,
+		},
+		ExpectStatus: http.StatusUnauthorized,
+		FailPrefix:   "POST /login: ",
 	})
-
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/login", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /login: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /login: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, http.StatusUnauthorized)
-	if err != nil {
-		return fails.NewError(err, "POST /login: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /login: JSONデコードに失敗しました")
-	}
-
-	return nil
 }
 
-func (s *Session) SellWithWrongCSRFToken(name string, price int, description string, categoryID int) error {
-	b, _ := json.Marshal(reqSell{
-		CSRFToken:   secureRandomStr(20),
-		Name:        name,
-		Price:       price,
-		Description: description,
-		CategoryID:  categoryID,
+func (s *Session) SellWithWrongCSRFToken(ctx context.Context, name string, price int, description string, categoryID int) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/sell",
+		Body: reqSell{
+			CSRFToken:   s.csrfToken,
+			Name:        name,
+			Price:       price,
+			Description: description,
+			CategoryID:  categoryID,
+		},
+		ExpectStatus: http.StatusUnprocessableEntity,
+		CSRFOverride: secureRandomStr(20),
+		FailPrefix:   "POST /sell: ",
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/sell", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /sell: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /sell: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, http.StatusUnprocessableEntity)
-	if err != nil {
-		return fails.NewError(err, "POST /sell: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /sell: JSONデコードに失敗しました")
-	}
-
-	return nil
 }
 
-func (s *Session) SellWithWrongPrice(name string, price int, description string, categoryID int) error {
-	b, _ := json.Marshal(reqSell{
-		CSRFToken:   s.csrfToken,
-		Name:        name,
-		Price:       price,
-		Description: description,
-		CategoryID:  categoryID,
+func (s *Session) SellWithWrongPrice(ctx context.Context, name string, price int, description string, categoryID int) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/sell",
+		Body: reqSell{
+			CSRFToken:   s.csrfToken,
+			Name:        name,
+			Price:       price,
+			Description: description,
+			CategoryID:  categoryID,
+		},
+		ExpectStatus:   http.StatusBadRequest,
+		ExpectErrorMsg: ItemPriceErrMsg,
+		FailPrefix:     "POST /sell: ",
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/sell", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /sell: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /sell: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, http.StatusBadRequest)
-	if err != nil {
-		return fails.NewError(err, "POST /sell: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /sell: JSONデコードに失敗しました")
-	}
-
-	if re.Error != ItemPriceErrMsg {
-		return fails.NewError(err, "POST /sell: 商品価格は100円以上、1,000,000円以下しか出品できません")
-	}
-
-	return nil
 }
 
-func (s *Session) BuyWithWrongCSRFToken(itemID int64, token string) error {
-	b, _ := json.Marshal(reqBuy{
-		CSRFToken: secureRandomStr(20),
-		ItemID:    itemID,
-		Token:     token,
+func (s *Session) BuyWithWrongCSRFToken(ctx context.Context, itemID int64, token string) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/buy",
+		Body: reqBuy{
+			CSRFToken: s.csrfToken,
+			ItemID:    itemID,
+			Token:     token,
+		},
+		ExpectStatus: http.StatusUnprocessableEntity,
+		CSRFOverride: secureRandomStr(20),
+		FailPrefix:   "POST /buy: ",
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /buy: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /buy: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, http.StatusUnprocessableEntity)
-	if err != nil {
-		return fails.NewError(err, "POST /buy: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /buy: JSONデコードに失敗しました")
-	}
-
-	return nil
 }
 
-func (s *Session) BuyWithFailed(itemID int64, token string, expectedStatus int, expectedMsg string) error {
-	b, _ := json.Marshal(reqBuy{
-		CSRFToken: s.csrfToken,
-		ItemID:    itemID,
-		Token:     token,
+func (s *Session) BuyWithFailed(ctx context.Context, itemID int64, token string, expectedStatus int, expectedMsg string) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/buy",
+		Body: reqBuy{
+			CSRFToken: s.csrfToken,
+			ItemID:    itemID,
+			Token:     token,
+		},
+		ExpectStatus:   expectedStatus,
+		ExpectErrorMsg: expectedMsg,
+		FailPrefix:     "POST /buy: ",
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /buy: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /buy: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, expectedStatus)
-	if err != nil {
-		return fails.NewError(err, "POST /buy: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /buy: JSONデコードに失敗しました")
-	}
-
-	if re.Error != expectedMsg {
-		return fails.NewError(err, "POST /buy: "+expectedMsg+"というエラーではありません")
-	}
-
-	return nil
 }
 
-func (s *Session) ShipWithWrongCSRFToken(itemID int64) error {
-	b, _ := json.Marshal(reqShip{
-		CSRFToken: secureRandomStr(20),
-		ItemID:    itemID,
+func (s *Session) ShipWithWrongCSRFToken(ctx context.Context, itemID int64) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/ship",
+		Body: reqShip{
+			CSRFToken: s.csrfToken,
+			ItemID:    itemID,
+		},
+		ExpectStatus: http.StatusUnprocessableEntity,
+		CSRFOverride: secureRandomStr(20),
+		FailPrefix:   "POST /ship: ",
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /ship: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /ship: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, http.StatusUnprocessableEntity)
-	if err != nil {
-		return fails.NewError(err, "POST /ship: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /ship: JSONデコードに失敗しました")
-	}
-
-	return nil
 }
 
-func (s *Session) ShipWithWrongSeller(itemID int64) error {
-	b, _ := json.Marshal(reqShip{
-		CSRFToken: secureRandomStr(20),
-		ItemID:    itemID,
+func (s *Session) ShipWithWrongSeller(ctx context.Context, itemID int64) error {
+	return s.runNegative(ctx, NegativeCase{
+		Path: "/ship",
+		Body: reqShip{
+			CSRFToken: s.csrfToken,
+			ItemID:    itemID,
+		},
+		ExpectStatus:   http.StatusForbidden,
+		ExpectErrorMsg: "権限がありません",
+		CSRFOverride:   secureRandomStr(20),
+		FailPrefix:     "POST /ship: ",
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship", "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return fails.NewError(err, "POST /ship: リクエストに失敗しました")
-	}
-
-	res, err := s.Do(req)
-	if err != nil {
-		return fails.NewError(err, "POST /ship: リクエストに失敗しました")
-	}
-	defer res.Body.Close()
-
-	msg, err := checkStatusCode(res, http.StatusForbidden)
-	if err != nil {
-		return fails.NewError(err, "POST /ship: "+msg)
-	}
-
-	re := resErr{}
-	err = json.NewDecoder(res.Body).Decode(&re)
-	if err != nil {
-		return fails.NewError(err, "POST /ship: JSONデコードに失敗しました")
-	}
-
-	if re.Error != "権限がありません" {
-		return fails.NewError(err, "POST /ship: 権限がないエラーが発生していません")
-	}
-
-	return nil
 }